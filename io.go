@@ -0,0 +1,290 @@
+package pagerank
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ReadEdgeList streams edges from r into the graph, calling parse on each
+// non-empty line to produce a (source, target, weight) triple and then
+// Link to add it. Lines are processed one at a time via bufio.Scanner, so
+// the whole file is never buffered in memory, making this suitable for
+// large edge lists.
+func (self *Graph[T]) ReadEdgeList(r io.Reader, parse func(string) (T, T, float64, error)) error {
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		source, target, weight, err := parse(line)
+		if err != nil {
+			return err
+		}
+
+		self.Link(source, target, weight)
+	}
+
+	return scanner.Err()
+}
+
+// WriteEdgeList writes every edge in the graph to w, formatting each
+// (source, target, weight) triple with format. It is the counterpart to
+// ReadEdgeList.
+func (self *Graph[T]) WriteEdgeList(w io.Writer, format func(source, target T, weight float64) string) error {
+	for source, targets := range self.edges {
+		for target, weight := range targets {
+			if _, err := io.WriteString(w, format(source, target, weight)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReadTSV reads a tab-separated edge list (src<TAB>dst<TAB>weight) of
+// string node ids into g.
+func ReadTSV(g *Graph[string], r io.Reader) error {
+	return g.ReadEdgeList(r, func(line string) (string, string, float64, error) {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return "", "", 0, fmt.Errorf("pagerank: malformed tsv line %q", line)
+		}
+
+		weight, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return "", "", 0, fmt.Errorf("pagerank: malformed tsv weight %q: %w", fields[2], err)
+		}
+
+		return fields[0], fields[1], weight, nil
+	})
+}
+
+// WriteTSV writes g as a tab-separated edge list (src<TAB>dst<TAB>weight)
+// of string node ids.
+func WriteTSV(g *Graph[string], w io.Writer) error {
+	return g.WriteEdgeList(w, func(source, target string, weight float64) string {
+		return fmt.Sprintf("%s\t%s\t%g\n", source, target, weight)
+	})
+}
+
+// ReadTSVInt64 is ReadTSV for graphs keyed by int64 node ids.
+func ReadTSVInt64(g *Graph[int64], r io.Reader) error {
+	return g.ReadEdgeList(r, func(line string) (int64, int64, float64, error) {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return 0, 0, 0, fmt.Errorf("pagerank: malformed tsv line %q", line)
+		}
+
+		source, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("pagerank: malformed tsv source %q: %w", fields[0], err)
+		}
+
+		target, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("pagerank: malformed tsv target %q: %w", fields[1], err)
+		}
+
+		weight, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("pagerank: malformed tsv weight %q: %w", fields[2], err)
+		}
+
+		return source, target, weight, nil
+	})
+}
+
+// WriteTSVInt64 is WriteTSV for graphs keyed by int64 node ids.
+func WriteTSVInt64(g *Graph[int64], w io.Writer) error {
+	return g.WriteEdgeList(w, func(source, target int64, weight float64) string {
+		return fmt.Sprintf("%d\t%d\t%g\n", source, target, weight)
+	})
+}
+
+// ReadMatrixMarket reads a Matrix Market coordinate file, as used by
+// wt2g-style inlink datasets, into g. Each non-comment data line after the
+// size line is read as "row col [weight]" (1-indexed), with a missing
+// weight defaulting to 1.
+func ReadMatrixMarket(g *Graph[int64], r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	sawSize := false
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "%") {
+			continue
+		}
+
+		if !sawSize {
+			// The "rows cols nnz" size line isn't needed to stream edges.
+			sawSize = true
+
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return fmt.Errorf("pagerank: malformed matrix market line %q", line)
+		}
+
+		row, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("pagerank: malformed matrix market row %q: %w", fields[0], err)
+		}
+
+		col, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("pagerank: malformed matrix market column %q: %w", fields[1], err)
+		}
+
+		weight := float64(1)
+
+		if len(fields) >= 3 {
+			weight, err = strconv.ParseFloat(fields[2], 64)
+			if err != nil {
+				return fmt.Errorf("pagerank: malformed matrix market weight %q: %w", fields[2], err)
+			}
+		}
+
+		g.Link(row, col, weight)
+	}
+
+	return scanner.Err()
+}
+
+// WriteMatrixMarket writes g as a Matrix Market coordinate file, using the
+// node's int64 value as its 1-indexed row/column id.
+func WriteMatrixMarket(g *Graph[int64], w io.Writer) error {
+	nnz := 0
+	for _, targets := range g.edges {
+		nnz += len(targets)
+	}
+
+	if _, err := fmt.Fprintf(w, "%%%%MatrixMarket matrix coordinate real general\n%d %d %d\n", len(g.nodes), len(g.nodes), nnz); err != nil {
+		return err
+	}
+
+	return g.WriteEdgeList(w, func(source, target int64, weight float64) string {
+		return fmt.Sprintf("%d %d %g\n", source, target, weight)
+	})
+}
+
+// ReadGraphML streams a GraphML document into g, using string node ids and
+// an optional "weight" data element on each edge (defaulting to 1 when
+// absent). Nodes with no incident edge are not represented in GraphML's
+// edge list and so are not added to g. Edges are read one xml.Token at a
+// time via xml.Decoder, so the whole document is never buffered in memory.
+func ReadGraphML(g *Graph[string], r io.Reader) error {
+	decoder := xml.NewDecoder(r)
+
+	inEdge := false
+	source, target := "", ""
+	weight := float64(1)
+	dataKey := ""
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("pagerank: malformed graphml: %w", err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "edge":
+				inEdge = true
+				source, target, weight = "", "", 1
+
+				for _, attr := range element.Attr {
+					switch attr.Name.Local {
+					case "source":
+						source = attr.Value
+					case "target":
+						target = attr.Value
+					}
+				}
+			case "data":
+				if inEdge {
+					for _, attr := range element.Attr {
+						if attr.Name.Local == "key" {
+							dataKey = attr.Value
+						}
+					}
+				}
+			}
+		case xml.CharData:
+			if inEdge && dataKey == "weight" {
+				parsed, err := strconv.ParseFloat(strings.TrimSpace(string(element)), 64)
+				if err != nil {
+					return fmt.Errorf("pagerank: malformed graphml weight %q: %w", string(element), err)
+				}
+
+				weight = parsed
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "data":
+				dataKey = ""
+			case "edge":
+				if inEdge {
+					g.Link(source, target, weight)
+					inEdge = false
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// WriteGraphML writes g as a GraphML document, with each edge carrying its
+// weight as a "weight" data element.
+func WriteGraphML(g *Graph[string], w io.Writer) error {
+	if _, err := io.WriteString(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"+
+		"<graphml xmlns=\"http://graphml.graphdrawing.org/xmlns\">\n"+
+		"  <graph edgedefault=\"directed\">\n"); err != nil {
+		return err
+	}
+
+	for key := range g.nodes {
+		if _, err := fmt.Fprintf(w, "    <node id=\"%s\"/>\n", escapeXML(key)); err != nil {
+			return err
+		}
+	}
+
+	err := g.WriteEdgeList(w, func(source, target string, weight float64) string {
+		return fmt.Sprintf(
+			"    <edge source=\"%s\" target=\"%s\"><data key=\"weight\">%g</data></edge>\n",
+			escapeXML(source), escapeXML(target), weight,
+		)
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "  </graph>\n</graphml>\n")
+
+	return err
+}
+
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+
+	_ = xml.EscapeText(&buf, []byte(s))
+
+	return buf.String()
+}
@@ -0,0 +1,200 @@
+package pagerank
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTSVRoundTrip(t *testing.T) {
+	graph := NewGraph[string]()
+	graph.Link("a", "b", 1)
+	graph.Link("b", "c", 2.5)
+
+	var buf bytes.Buffer
+	if err := WriteTSV(graph, &buf); err != nil {
+		t.Fatalf("WriteTSV: unexpected error: %v", err)
+	}
+
+	got := NewGraph[string]()
+	if err := ReadTSV(got, &buf); err != nil {
+		t.Fatalf("ReadTSV: unexpected error: %v", err)
+	}
+
+	for source, targets := range graph.edges {
+		for target, weight := range targets {
+			if got.edges[source][target] != weight {
+				t.Fatalf("edge %s->%s = %v, want %v", source, target, got.edges[source][target], weight)
+			}
+		}
+	}
+}
+
+func TestReadTSVRejectsMalformedLine(t *testing.T) {
+	graph := NewGraph[string]()
+
+	err := ReadTSV(graph, strings.NewReader("a\tb\n"))
+	if err == nil {
+		t.Fatal("expected an error for a line missing the weight field")
+	}
+}
+
+func TestReadTSVRejectsMalformedWeight(t *testing.T) {
+	graph := NewGraph[string]()
+
+	err := ReadTSV(graph, strings.NewReader("a\tb\tnotanumber\n"))
+	if err == nil {
+		t.Fatal("expected an error for a non-numeric weight")
+	}
+}
+
+func TestTSVInt64RoundTrip(t *testing.T) {
+	graph := NewGraph[int64]()
+	graph.Link(1, 2, 1)
+	graph.Link(2, 3, 3)
+
+	var buf bytes.Buffer
+	if err := WriteTSVInt64(graph, &buf); err != nil {
+		t.Fatalf("WriteTSVInt64: unexpected error: %v", err)
+	}
+
+	got := NewGraph[int64]()
+	if err := ReadTSVInt64(got, &buf); err != nil {
+		t.Fatalf("ReadTSVInt64: unexpected error: %v", err)
+	}
+
+	for source, targets := range graph.edges {
+		for target, weight := range targets {
+			if got.edges[source][target] != weight {
+				t.Fatalf("edge %d->%d = %v, want %v", source, target, got.edges[source][target], weight)
+			}
+		}
+	}
+}
+
+func TestReadTSVInt64RejectsMalformedID(t *testing.T) {
+	graph := NewGraph[int64]()
+
+	err := ReadTSVInt64(graph, strings.NewReader("a\t2\t1\n"))
+	if err == nil {
+		t.Fatal("expected an error for a non-numeric source id")
+	}
+}
+
+func TestMatrixMarketRoundTrip(t *testing.T) {
+	graph := NewGraph[int64]()
+	graph.Link(1, 2, 1)
+	graph.Link(2, 3, 4)
+
+	var buf bytes.Buffer
+	if err := WriteMatrixMarket(graph, &buf); err != nil {
+		t.Fatalf("WriteMatrixMarket: unexpected error: %v", err)
+	}
+
+	got := NewGraph[int64]()
+	if err := ReadMatrixMarket(got, &buf); err != nil {
+		t.Fatalf("ReadMatrixMarket: unexpected error: %v", err)
+	}
+
+	for source, targets := range graph.edges {
+		for target, weight := range targets {
+			if got.edges[source][target] != weight {
+				t.Fatalf("edge %d->%d = %v, want %v", source, target, got.edges[source][target], weight)
+			}
+		}
+	}
+}
+
+func TestReadMatrixMarketDefaultsMissingWeightToOne(t *testing.T) {
+	graph := NewGraph[int64]()
+
+	err := ReadMatrixMarket(graph, strings.NewReader("%%MatrixMarket matrix coordinate real general\n2 2 1\n1 2\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if weight := graph.edges[1][2]; weight != 1 {
+		t.Fatalf("edge 1->2 = %v, want 1", weight)
+	}
+}
+
+func TestReadMatrixMarketRejectsMalformedLine(t *testing.T) {
+	graph := NewGraph[int64]()
+
+	err := ReadMatrixMarket(graph, strings.NewReader("%%MatrixMarket matrix coordinate real general\n1 1 1\n1\n"))
+	if err == nil {
+		t.Fatal("expected an error for a line missing the column field")
+	}
+}
+
+func TestGraphMLRoundTrip(t *testing.T) {
+	graph := NewGraph[string]()
+	graph.Link("a", "b", 1)
+	graph.Link("b", "c", 2.5)
+
+	var buf bytes.Buffer
+	if err := WriteGraphML(graph, &buf); err != nil {
+		t.Fatalf("WriteGraphML: unexpected error: %v", err)
+	}
+
+	got := NewGraph[string]()
+	if err := ReadGraphML(got, &buf); err != nil {
+		t.Fatalf("ReadGraphML: unexpected error: %v", err)
+	}
+
+	for source, targets := range graph.edges {
+		for target, weight := range targets {
+			if got.edges[source][target] != weight {
+				t.Fatalf("edge %s->%s = %v, want %v", source, target, got.edges[source][target], weight)
+			}
+		}
+	}
+}
+
+func TestReadGraphMLDefaultsMissingWeightToOne(t *testing.T) {
+	graph := NewGraph[string]()
+
+	const doc = `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <graph edgedefault="directed">
+    <node id="a"/>
+    <node id="b"/>
+    <edge source="a" target="b"/>
+  </graph>
+</graphml>
+`
+
+	if err := ReadGraphML(graph, strings.NewReader(doc)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if weight := graph.edges["a"]["b"]; weight != 1 {
+		t.Fatalf("edge a->b = %v, want 1", weight)
+	}
+}
+
+func TestReadGraphMLRejectsMalformedWeight(t *testing.T) {
+	graph := NewGraph[string]()
+
+	const doc = `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <graph edgedefault="directed">
+    <edge source="a" target="b"><data key="weight">notanumber</data></edge>
+  </graph>
+</graphml>
+`
+
+	err := ReadGraphML(graph, strings.NewReader(doc))
+	if err == nil {
+		t.Fatal("expected an error for a non-numeric weight")
+	}
+}
+
+func TestReadGraphMLRejectsMalformedXML(t *testing.T) {
+	graph := NewGraph[string]()
+
+	err := ReadGraphML(graph, strings.NewReader("<graphml><graph>"))
+	if err == nil {
+		t.Fatal("expected an error for truncated xml")
+	}
+}
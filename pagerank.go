@@ -4,7 +4,13 @@ Package pagerank implements the *weighted* PageRank algorithm.
 package pagerank
 
 import (
+	"context"
+	"fmt"
 	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
 )
 
 type node struct {
@@ -14,8 +20,9 @@ type node struct {
 
 // Graph holds node and edge data.
 type Graph[T comparable] struct {
-	edges map[T](map[T]float64)
-	nodes map[T]*node
+	edges      map[T](map[T]float64)
+	nodes      map[T]*node
+	normalized bool
 }
 
 // NewGraph initializes and returns a new graph.
@@ -52,26 +59,182 @@ func (self *Graph[T]) Link(source, target T, weight float64) {
 	self.edges[source][target] += weight
 }
 
+// Prepare normalizes the edge weights and seeds the initial PageRank
+// distribution, the setup work that Rank and RankN each need before they can
+// start stepping. It is safe to call more than once: edge weights are only
+// normalized the first time, and a node's weight is only reset to the
+// uniform 1/N value if it doesn't already have one. This lets a caller warm-
+// start from a previous result by calling Link to add new edges and then
+// Prepare again, rather than recomputing from scratch.
+func (self *Graph[T]) Prepare() {
+	if !self.normalized {
+		for source := range self.edges {
+			if self.nodes[source].outbound > 0 {
+				for target := range self.edges[source] {
+					self.edges[source][target] /= self.nodes[source].outbound
+				}
+			}
+		}
+
+		self.normalized = true
+	}
+
+	inverse := 1 / float64(len(self.nodes))
+
+	for _, value := range self.nodes {
+		if value.weight == 0 {
+			value.weight = inverse
+		}
+	}
+}
+
+// Step runs a single power-iteration update of the PageRank weights and
+// returns Δ, the L1 change from the previous iteration. Prepare must be
+// called at least once before Step; driving Step directly (rather than
+// through Rank or RankN) lets a caller snapshot intermediate weights or
+// control iteration externally.
+func (self *Graph[T]) Step(α float64) float64 {
+	return self.step(α, NormL1)
+}
+
+// step is the shared body behind Step and RankWithOptions; norm selects how
+// the returned Δ is computed.
+func (self *Graph[T]) step(α float64, norm Norm) float64 {
+	leak := float64(0)
+	nodes := map[T]float64{}
+	inverse := 1 / float64(len(self.nodes))
+
+	for key, value := range self.nodes {
+		nodes[key] = value.weight
+
+		if value.outbound == 0 {
+			leak += value.weight
+		}
+
+		self.nodes[key].weight = 0
+	}
+
+	leak *= α
+
+	for source := range self.nodes {
+		for target, weight := range self.edges[source] {
+			self.nodes[target].weight += α * nodes[source] * weight
+		}
+
+		self.nodes[source].weight += (1-α)*inverse + leak*inverse
+	}
+
+	Δ := float64(0)
+
+	for key, value := range self.nodes {
+		diff := value.weight - nodes[key]
+
+		if norm == NormL2 {
+			Δ += diff * diff
+		} else {
+			Δ += math.Abs(diff)
+		}
+	}
+
+	if norm == NormL2 {
+		Δ = math.Sqrt(Δ)
+	}
+
+	return Δ
+}
+
 // Rank computes the PageRank of every node in the directed graph.
 // α (alpha) is the damping factor, usually set to 0.85.
 // ε (epsilon) is the convergence criteria, usually set to a tiny value.
+// It returns the number of iterations run and the final Δ.
+//
+// This method will run as many iterations as needed, until the graph
+// converges; it is a thin wrapper around RankWithOptions with an
+// unbounded, uncancellable context.Background(), so err is always nil here
+// — see RankWithOptions for a cancellable or iteration-capped variant.
+func (self *Graph[T]) Rank(α, ε float64, callback func(id T, rank float64)) (int, float64, error) {
+	return self.RankWithOptions(context.Background(), RankOptions{Alpha: α, Epsilon: ε}, callback)
+}
+
+// RankN computes the PageRank of every node using a fixed number of
+// iterations instead of iterating to convergence, mirroring the
+// fixed-iteration mode common in large-scale, Pregel-style PageRank
+// implementations. α (alpha) is the damping factor, usually set to 0.85.
+//
+// This is useful for streaming graphs where edges arrive continuously:
+// call Link for the new edges and then RankN to warm-start a bounded number
+// of additional steps from the existing weights, rather than recomputing
+// from scratch with Rank.
+func (self *Graph[T]) RankN(α float64, iterations int, callback func(id T, rank float64)) {
+	self.Prepare()
+
+	for i := 0; i < iterations; i++ {
+		self.Step(α)
+	}
+
+	for key, value := range self.nodes {
+		callback(key, value.weight)
+	}
+}
+
+// RankPersonalized computes a personalized variant of PageRank, replacing the
+// uniform 1/N teleport term with a caller-supplied probability distribution
+// over nodes. α (alpha) is the damping factor, usually set to 0.85.
+// ε (epsilon) is the convergence criteria, usually set to a tiny value.
+//
+// teleport assigns a probability mass to the nodes that should receive
+// teleport and dangling-node leak; it is normalized internally so its values
+// sum to 1, which also lets callers pass unnormalized weights (e.g. a
+// TrustRank seed set). Nodes absent from teleport receive no teleport mass.
+// An error is returned if teleport references a node not in the graph,
+// contains a negative entry, or sums to zero.
 //
 // This method will run as many iterations as needed, until the graph converges.
-func (self *Graph[T]) Rank(α, ε float64, callback func(id T, rank float64)) {
+func (self *Graph[T]) RankPersonalized(α, ε float64, teleport map[T]float64, callback func(id T, rank float64)) error {
+	sum := float64(0)
+
+	for key, value := range teleport {
+		if _, ok := self.nodes[key]; !ok {
+			return fmt.Errorf("pagerank: teleport references unknown node %v", key)
+		}
+
+		if value < 0 {
+			return fmt.Errorf("pagerank: negative teleport weight for node %v", key)
+		}
+
+		sum += value
+	}
+
+	if sum == 0 {
+		return fmt.Errorf("pagerank: teleport distribution must sum to a positive value")
+	}
+
+	distribution := make(map[T]float64, len(teleport))
+
+	for key, value := range teleport {
+		distribution[key] = value / sum
+	}
+
 	Δ := float64(1.0)
-	inverse := 1 / float64(len(self.nodes))
 
-	// Normalize all the edge weights so that their sum amounts to 1.
-	for source := range self.edges {
-		if self.nodes[source].outbound > 0 {
-			for target := range self.edges[source] {
-				self.edges[source][target] /= self.nodes[source].outbound
+	// Normalize all the edge weights so that their sum amounts to 1. Like
+	// Prepare, this only needs to happen once: repeating it on a graph
+	// Rank/RankN/RankPersonalized already normalized would divide the
+	// already-normalized weights by outbound a second time.
+	if !self.normalized {
+		for source := range self.edges {
+			if self.nodes[source].outbound > 0 {
+				for target := range self.edges[source] {
+					self.edges[source][target] /= self.nodes[source].outbound
+				}
 			}
 		}
+
+		self.normalized = true
 	}
 
 	for key := range self.nodes {
-		self.nodes[key].weight = inverse
+		self.nodes[key].weight = distribution[key]
 	}
 
 	for Δ > ε {
@@ -95,7 +258,7 @@ func (self *Graph[T]) Rank(α, ε float64, callback func(id T, rank float64)) {
 				self.nodes[target].weight += α * nodes[source] * weight
 			}
 
-			self.nodes[source].weight += (1-α)*inverse + leak*inverse
+			self.nodes[source].weight += (1-α)*distribution[source] + leak*distribution[source]
 		}
 
 		Δ = 0
@@ -108,10 +271,483 @@ func (self *Graph[T]) Rank(α, ε float64, callback func(id T, rank float64)) {
 	for key, value := range self.nodes {
 		callback(key, value.weight)
 	}
+
+	return nil
+}
+
+// Norm selects the vector norm RankWithOptions uses to measure per-iteration
+// convergence.
+type Norm int
+
+const (
+	// NormL1 sums the absolute per-node weight change: the Δ that Step
+	// returns and the convergence criterion Rank uses.
+	NormL1 Norm = iota
+	// NormL2 is the gonum-style 2-norm: the square root of the sum of
+	// squared per-node weight changes.
+	NormL2
+)
+
+// RankOptions configures RankWithOptions.
+type RankOptions struct {
+	// Alpha is the damping factor, usually set to 0.85.
+	Alpha float64
+	// Epsilon is the convergence criterion, usually set to a tiny value.
+	Epsilon float64
+	// MaxIterations bounds the number of iterations RankWithOptions runs
+	// before giving up. Zero or negative means unbounded. Without a cap, an
+	// Epsilon set too tight for float64 precision can make the loop spin
+	// indefinitely.
+	MaxIterations int
+	// Norm selects the convergence norm; the zero value is NormL1.
+	Norm Norm
+	// OnIteration, if non-nil, is called after every iteration with the
+	// 1-based iteration number and that iteration's Δ, e.g. to plot
+	// convergence.
+	OnIteration func(iteration int, delta float64)
+}
+
+// RankWithOptions computes the PageRank of every node in the directed
+// graph, like Rank, but adds cancellation via ctx, an iteration cap, a
+// choice of convergence norm, and an OnIteration hook. It returns the
+// number of iterations run and the final Δ. The returned error is ctx.Err()
+// if ctx was cancelled, an error if opts.MaxIterations was reached before
+// converging, or nil on convergence.
+func (self *Graph[T]) RankWithOptions(ctx context.Context, opts RankOptions, callback func(id T, rank float64)) (int, float64, error) {
+	self.Prepare()
+
+	emit := func() {
+		for key, value := range self.nodes {
+			callback(key, value.weight)
+		}
+	}
+
+	Δ := float64(1.0)
+	iterations := 0
+
+	for Δ > opts.Epsilon {
+		if err := ctx.Err(); err != nil {
+			emit()
+
+			return iterations, Δ, err
+		}
+
+		if opts.MaxIterations > 0 && iterations >= opts.MaxIterations {
+			emit()
+
+			return iterations, Δ, fmt.Errorf("pagerank: did not converge within %d iterations (Δ=%v)", opts.MaxIterations, Δ)
+		}
+
+		Δ = self.step(opts.Alpha, opts.Norm)
+		iterations++
+
+		if opts.OnIteration != nil {
+			opts.OnIteration(iterations, Δ)
+		}
+	}
+
+	emit()
+
+	return iterations, Δ, nil
+}
+
+// aliasTable draws weighted samples over a fixed set of targets in O(1) per
+// draw using Vose's alias method, built once from a source node's
+// (already-normalized) outgoing edge weights.
+type aliasTable[T comparable] struct {
+	targets []T
+	prob    []float64
+	alias   []int
+}
+
+func newAliasTable[T comparable](weights map[T]float64) *aliasTable[T] {
+	n := len(weights)
+
+	table := &aliasTable[T]{
+		targets: make([]T, 0, n),
+		prob:    make([]float64, n),
+		alias:   make([]int, n),
+	}
+
+	scaled := make([]float64, 0, n)
+	small := make([]int, 0, n)
+	large := make([]int, 0, n)
+
+	for target := range weights {
+		table.targets = append(table.targets, target)
+	}
+
+	// Order targets by their string representation, as RankRandomWalk does
+	// for its start nodes, so that a seeded rng draws the same targets on
+	// every run regardless of map iteration order.
+	sort.Slice(table.targets, func(i, j int) bool {
+		return fmt.Sprint(table.targets[i]) < fmt.Sprint(table.targets[j])
+	})
+
+	for _, target := range table.targets {
+		scaled = append(scaled, weights[target]*float64(n))
+	}
+
+	for i, p := range scaled {
+		if p < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		table.prob[s] = scaled[s]
+		table.alias[s] = l
+
+		scaled[l] += scaled[s] - 1
+
+		if scaled[l] < 1 {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+
+	for len(large) > 0 {
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+		table.prob[l] = 1
+	}
+
+	for len(small) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		table.prob[s] = 1
+	}
+
+	return table
+}
+
+// sample draws a single weighted target from the table.
+func (self *aliasTable[T]) sample(rng *rand.Rand) T {
+	i := rng.Intn(len(self.targets))
+
+	if rng.Float64() < self.prob[i] {
+		return self.targets[i]
+	}
+
+	return self.targets[self.alias[i]]
+}
+
+// RankRandomWalk estimates the PageRank of every node by Monte Carlo
+// simulation of the random surfer, rather than power iteration.
+// teleportProbability is the chance a walker stops at each step; it plays
+// the same role as (1-α) in Rank/RankN/RankPersonalized/CompactGraph.Rank,
+// where α is instead the link-follow (damping) probability — it is named
+// differently here, rather than reused as α, specifically so a value like
+// 0.85 can't be copied between the two APIs and silently invert its
+// meaning. walks is the number of independent walkers to simulate; more
+// walks trade runtime for accuracy. rng supplies the randomness, so callers
+// can seed it for reproducibility. All walks run sequentially on rng;
+// callback receives each node's share of the total visits, and the raw
+// visit counts are not exposed.
+//
+// Each walker starts at a node chosen uniformly at random. At every step it
+// stops with probability teleportProbability, and otherwise follows a
+// weighted outgoing edge selected via a precomputed alias table over
+// edges[source]; a dangling node (no outgoing edges) also stops the walk.
+// This geometric stopping rule makes the estimator embarrassingly parallel
+// and scales to graphs too large for dense power iteration.
+func (self *Graph[T]) RankRandomWalk(teleportProbability float64, walks int, rng *rand.Rand, callback func(id T, rank float64)) {
+	if len(self.nodes) == 0 {
+		return
+	}
+
+	if !self.normalized {
+		for source := range self.edges {
+			if self.nodes[source].outbound > 0 {
+				for target := range self.edges[source] {
+					self.edges[source][target] /= self.nodes[source].outbound
+				}
+			}
+		}
+
+		self.normalized = true
+	}
+
+	// Map iteration order is randomized per run, so build keys in a stable
+	// order keyed by each node's string representation; otherwise the same
+	// rng seed would pick different start nodes on different runs, breaking
+	// the reproducibility this method's rng parameter promises.
+	type labeledKey struct {
+		id    T
+		label string
+	}
+
+	labeled := make([]labeledKey, 0, len(self.nodes))
+	for key := range self.nodes {
+		labeled = append(labeled, labeledKey{id: key, label: fmt.Sprint(key)})
+	}
+
+	sort.Slice(labeled, func(i, j int) bool {
+		return labeled[i].label < labeled[j].label
+	})
+
+	keys := make([]T, len(labeled))
+	for i, entry := range labeled {
+		keys[i] = entry.id
+	}
+
+	tables := make(map[T]*aliasTable[T], len(self.edges))
+
+	for source, targets := range self.edges {
+		if len(targets) > 0 {
+			tables[source] = newAliasTable(targets)
+		}
+	}
+
+	visits := make(map[T]float64, len(self.nodes))
+
+	for i := 0; i < walks; i++ {
+		current := keys[rng.Intn(len(keys))]
+
+		for {
+			visits[current]++
+
+			table, ok := tables[current]
+			if !ok || rng.Float64() < teleportProbability {
+				break
+			}
+
+			current = table.sample(rng)
+		}
+	}
+
+	total := float64(0)
+	for _, count := range visits {
+		total += count
+	}
+
+	for _, key := range keys {
+		callback(key, visits[key]/total)
+	}
+}
+
+// CompactGraph is a read-only, CSR-backed representation of a Graph, built
+// via Compile for fast PageRank computation on large graphs. Edges are
+// stored as flat rowPtr/colIdx/weights slices indexed by a T<->int32
+// bijection instead of a map-of-maps, which lets the per-iteration sparse
+// matrix-vector product be sharded across goroutines and avoids the
+// per-iteration map allocation that Graph.Step incurs.
+type CompactGraph[T comparable] struct {
+	ids     []T
+	index   map[T]int32
+	rowPtr  []int32
+	colIdx  []int32
+	weights []float64
+}
+
+// Compile builds a CompactGraph from the graph's current nodes and edges.
+// Edge weights are normalized by source out-degree at compile time, the
+// same normalization Prepare performs internally; like Prepare, this only
+// happens once, so calling Compile on a graph that Rank/RankN/
+// RankPersonalized/RankRandomWalk already normalized reuses those weights
+// instead of dividing by outbound a second time. CompactGraph is a
+// snapshot: it does not support incremental updates, so call Compile again
+// after further calls to Link.
+func (self *Graph[T]) Compile() *CompactGraph[T] {
+	if !self.normalized {
+		for source := range self.edges {
+			if self.nodes[source].outbound > 0 {
+				for target := range self.edges[source] {
+					self.edges[source][target] /= self.nodes[source].outbound
+				}
+			}
+		}
+
+		self.normalized = true
+	}
+
+	ids := make([]T, 0, len(self.nodes))
+	index := make(map[T]int32, len(self.nodes))
+
+	for key := range self.nodes {
+		index[key] = int32(len(ids))
+		ids = append(ids, key)
+	}
+
+	rowPtr := make([]int32, len(ids)+1)
+	colIdx := make([]int32, 0, len(self.edges))
+	weights := make([]float64, 0, len(self.edges))
+
+	for i, key := range ids {
+		for target, weight := range self.edges[key] {
+			colIdx = append(colIdx, index[target])
+			weights = append(weights, weight)
+		}
+
+		rowPtr[i+1] = int32(len(colIdx))
+	}
+
+	return &CompactGraph[T]{
+		ids:     ids,
+		index:   index,
+		rowPtr:  rowPtr,
+		colIdx:  colIdx,
+		weights: weights,
+	}
+}
+
+// Rank computes the PageRank of every node in the compact graph.
+// α (alpha) is the damping factor, usually set to 0.85.
+// ε (epsilon) is the convergence criteria, usually set to a tiny value.
+//
+// This method will run as many iterations as needed, until the graph
+// converges. The per-iteration sparse matrix-vector product is sharded
+// across GOMAXPROCS goroutines by source-row ranges, each accumulating into
+// its own partial weight buffer; the buffers are then summed into a second,
+// reused weight vector, and the Δ convergence check is likewise reduced
+// from per-shard partial sums.
+func (self *CompactGraph[T]) Rank(α, ε float64, callback func(id T, rank float64)) {
+	n := len(self.ids)
+	if n == 0 {
+		return
+	}
+
+	inverse := 1 / float64(n)
+	teleport := (1 - α) * inverse
+
+	current := make([]float64, n)
+	next := make([]float64, n)
+
+	for i := range current {
+		current[i] = inverse
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+
+	chunk := (n + workers - 1) / workers
+
+	partials := make([][]float64, workers)
+	for w := range partials {
+		partials[w] = make([]float64, n)
+	}
+
+	deltas := make([]float64, workers)
+
+	for Δ := float64(1.0); Δ > ε; {
+		leak := float64(0)
+
+		for i := 0; i < n; i++ {
+			if self.rowPtr[i] == self.rowPtr[i+1] {
+				leak += current[i]
+			}
+		}
+
+		leak *= α
+
+		var wg sync.WaitGroup
+
+		for w := 0; w < workers; w++ {
+			start := w * chunk
+
+			end := start + chunk
+			if end > n {
+				end = n
+			}
+
+			if start >= end {
+				continue
+			}
+
+			wg.Add(1)
+
+			go func(local []float64, start, end int) {
+				defer wg.Done()
+
+				for i := range local {
+					local[i] = 0
+				}
+
+				for source := start; source < end; source++ {
+					weight := current[source]
+					if weight == 0 {
+						continue
+					}
+
+					for e := self.rowPtr[source]; e < self.rowPtr[source+1]; e++ {
+						local[self.colIdx[e]] += α * weight * self.weights[e]
+					}
+				}
+			}(partials[w], start, end)
+		}
+
+		wg.Wait()
+
+		base := teleport + leak*inverse
+
+		for i := 0; i < n; i++ {
+			sum := base
+
+			for w := 0; w < workers; w++ {
+				sum += partials[w][i]
+			}
+
+			next[i] = sum
+		}
+
+		var wg2 sync.WaitGroup
+
+		for w := 0; w < workers; w++ {
+			start := w * chunk
+
+			end := start + chunk
+			if end > n {
+				end = n
+			}
+
+			if start >= end {
+				deltas[w] = 0
+
+				continue
+			}
+
+			wg2.Add(1)
+
+			go func(w, start, end int) {
+				defer wg2.Done()
+
+				d := float64(0)
+				for i := start; i < end; i++ {
+					d += math.Abs(next[i] - current[i])
+				}
+
+				deltas[w] = d
+			}(w, start, end)
+		}
+
+		wg2.Wait()
+
+		Δ = 0
+		for _, d := range deltas {
+			Δ += d
+		}
+
+		current, next = next, current
+	}
+
+	for i, id := range self.ids {
+		callback(id, current[i])
+	}
 }
 
 // Reset clears all the current graph data.
 func (self *Graph[T]) Reset() {
 	self.edges = make(map[T](map[T]float64))
 	self.nodes = make(map[T]*node)
+	self.normalized = false
 }
@@ -0,0 +1,457 @@
+package pagerank
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+func sumRanks[T comparable](ranks map[T]float64) float64 {
+	sum := float64(0)
+	for _, rank := range ranks {
+		sum += rank
+	}
+
+	return sum
+}
+
+func TestRankPersonalizedRepeatedCallsPreserveNormalization(t *testing.T) {
+	graph := NewGraph[string]()
+	graph.Link("a", "b", 1)
+	graph.Link("b", "a", 1)
+
+	teleport := map[string]float64{"a": 1, "b": 1}
+
+	for i := 0; i < 2; i++ {
+		ranks := map[string]float64{}
+
+		err := graph.RankPersonalized(0.85, 0.0001, teleport, func(id string, rank float64) {
+			ranks[id] = rank
+		})
+		if err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+
+		if sum := sumRanks(ranks); math.Abs(sum-1) > 1e-6 {
+			t.Fatalf("call %d: ranks summed to %v, want ~1", i, sum)
+		}
+	}
+}
+
+func TestRankPersonalizedThenRankStaysNormalized(t *testing.T) {
+	graph := NewGraph[string]()
+	graph.Link("a", "b", 1)
+	graph.Link("b", "a", 1)
+
+	teleport := map[string]float64{"a": 1, "b": 1}
+
+	if err := graph.RankPersonalized(0.85, 0.0001, teleport, func(string, float64) {}); err != nil {
+		t.Fatalf("RankPersonalized: unexpected error: %v", err)
+	}
+
+	ranks := map[string]float64{}
+	graph.Rank(0.85, 0.0001, func(id string, rank float64) {
+		ranks[id] = rank
+	})
+
+	if sum := sumRanks(ranks); math.Abs(sum-1) > 1e-6 {
+		t.Fatalf("ranks summed to %v after RankPersonalized, want ~1", sum)
+	}
+}
+
+func TestRankPersonalizedRejectsUnknownNode(t *testing.T) {
+	graph := NewGraph[string]()
+	graph.Link("a", "b", 1)
+
+	err := graph.RankPersonalized(0.85, 0.0001, map[string]float64{"a": 1, "missing": 1}, func(string, float64) {})
+	if err == nil {
+		t.Fatal("expected an error for a teleport key not present in the graph")
+	}
+}
+
+func TestRankRandomWalkDeterministicWithFixedSeed(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+	build := func() *Graph[string] {
+		graph := NewGraph[string]()
+
+		for i, name := range names {
+			graph.Link(name, names[(i+1)%len(names)], 1)
+		}
+
+		return graph
+	}
+
+	run := func() map[string]float64 {
+		ranks := map[string]float64{}
+
+		build().RankRandomWalk(0.15, 20000, rand.New(rand.NewSource(42)), func(id string, rank float64) {
+			ranks[id] = rank
+		})
+
+		return ranks
+	}
+
+	want := run()
+
+	for i := 0; i < 3; i++ {
+		got := run()
+
+		if len(got) != len(want) {
+			t.Fatalf("run %d: got %d ranks, want %d", i, len(got), len(want))
+		}
+
+		for id, rank := range want {
+			if got[id] != rank {
+				t.Fatalf("run %d: rank[%s] = %v, want %v (same seed should be deterministic)", i, id, got[id], rank)
+			}
+		}
+	}
+}
+
+func TestRankReturnsIterationsAndConverges(t *testing.T) {
+	graph := NewGraph[string]()
+	graph.Link("a", "b", 1)
+	graph.Link("b", "a", 1)
+
+	iterations, delta, err := graph.Rank(0.85, 1e-8, func(string, float64) {})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if iterations == 0 {
+		t.Fatal("expected at least one iteration")
+	}
+
+	if delta > 1e-8 {
+		t.Fatalf("final delta %v exceeds epsilon", delta)
+	}
+}
+
+func TestRankWithOptionsEmitsPartialResultsOnMaxIterations(t *testing.T) {
+	graph := NewGraph[string]()
+	graph.Link("a", "b", 1)
+	graph.Link("b", "a", 1)
+
+	ranks := map[string]float64{}
+
+	_, _, err := graph.RankWithOptions(context.Background(), RankOptions{
+		Alpha:         0.85,
+		Epsilon:       -1, // unreachable, so MaxIterations is what stops the loop
+		MaxIterations: 3,
+	}, func(id string, rank float64) {
+		ranks[id] = rank
+	})
+
+	if err == nil {
+		t.Fatal("expected an error when MaxIterations is reached before converging")
+	}
+
+	if len(ranks) != 2 {
+		t.Fatalf("expected partial ranks for both nodes, got %d", len(ranks))
+	}
+}
+
+func TestRankWithOptionsEmitsPartialResultsOnCancellation(t *testing.T) {
+	graph := NewGraph[string]()
+	graph.Link("a", "b", 1)
+	graph.Link("b", "a", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ranks := map[string]float64{}
+
+	_, _, err := graph.RankWithOptions(ctx, RankOptions{Alpha: 0.85, Epsilon: 1e-8}, func(id string, rank float64) {
+		ranks[id] = rank
+	})
+
+	if err == nil {
+		t.Fatal("expected an error for a cancelled context")
+	}
+
+	if len(ranks) != 2 {
+		t.Fatalf("expected partial ranks for both nodes, got %d", len(ranks))
+	}
+}
+
+func TestRankRandomWalkOnEmptyGraphIsNoOp(t *testing.T) {
+	graph := NewGraph[string]()
+
+	called := false
+	graph.RankRandomWalk(0.15, 100, rand.New(rand.NewSource(1)), func(string, float64) {
+		called = true
+	})
+
+	if called {
+		t.Fatal("expected callback not to be invoked for an empty graph")
+	}
+}
+
+func TestRankRandomWalkApproximatesExactRank(t *testing.T) {
+	build := func() *Graph[string] {
+		graph := NewGraph[string]()
+		graph.Link("a", "b", 1)
+		graph.Link("b", "a", 1)
+		graph.Link("b", "c", 1)
+		graph.Link("c", "b", 1)
+
+		return graph
+	}
+
+	exact := map[string]float64{}
+	build().Rank(0.85, 1e-10, func(id string, rank float64) {
+		exact[id] = rank
+	})
+
+	estimated := map[string]float64{}
+	build().RankRandomWalk(0.15, 200000, rand.New(rand.NewSource(7)), func(id string, rank float64) {
+		estimated[id] = rank
+	})
+
+	for id, rank := range exact {
+		if diff := math.Abs(estimated[id] - rank); diff > 0.02 {
+			t.Fatalf("rank[%s] estimated %v, exact %v (diff %v exceeds tolerance)", id, estimated[id], rank, diff)
+		}
+	}
+}
+
+func TestRankNMatchesRankAtConvergence(t *testing.T) {
+	build := func() *Graph[string] {
+		graph := NewGraph[string]()
+		graph.Link("a", "b", 1)
+		graph.Link("b", "c", 1)
+		graph.Link("c", "a", 1)
+		graph.Link("c", "b", 1)
+		graph.Link("b", "a", 2)
+
+		return graph
+	}
+
+	want := map[string]float64{}
+	build().Rank(0.85, 1e-10, func(id string, rank float64) {
+		want[id] = rank
+	})
+
+	got := map[string]float64{}
+	build().RankN(0.85, 200, func(id string, rank float64) {
+		got[id] = rank
+	})
+
+	for id, rank := range want {
+		if diff := math.Abs(got[id] - rank); diff > 1e-6 {
+			t.Fatalf("rank[%s] = %v, want %v (diff %v)", id, got[id], rank, diff)
+		}
+	}
+}
+
+func TestStepRequiresPrepareAndReportsDecreasingDelta(t *testing.T) {
+	graph := NewGraph[string]()
+	graph.Link("a", "b", 1)
+	graph.Link("b", "c", 1)
+	graph.Link("c", "a", 1)
+	graph.Link("c", "b", 1)
+	graph.Link("b", "a", 2)
+
+	graph.Prepare()
+
+	first := graph.Step(0.85)
+	if first <= 0 {
+		t.Fatalf("first Step delta = %v, want > 0", first)
+	}
+
+	var last float64
+	for i := 0; i < 50; i++ {
+		last = graph.Step(0.85)
+	}
+
+	if last >= first {
+		t.Fatalf("delta did not shrink from repeated Step calls: first=%v, last=%v", first, last)
+	}
+}
+
+// TestPrepareThenLinkThenPrepareWarmStarts exercises the warm-start path
+// Prepare's doc comment describes: Link a new node into an already-ranked
+// graph, call Prepare again, and keep stepping via Step — the existing
+// weights should carry forward and the new node should join a still-valid
+// distribution, rather than Prepare resetting already-seeded node weights.
+func TestPrepareThenLinkThenPrepareWarmStarts(t *testing.T) {
+	graph := NewGraph[string]()
+	graph.Link("a", "b", 1)
+	graph.Link("b", "a", 1)
+
+	graph.Prepare()
+
+	for i := 0; i < 20; i++ {
+		graph.Step(0.85)
+	}
+
+	graph.Link("c", "a", 1)
+
+	graph.Prepare()
+
+	for i := 0; i < 200; i++ {
+		graph.Step(0.85)
+	}
+
+	ranks := map[string]float64{}
+	for key, value := range graph.nodes {
+		ranks[key] = value.weight
+	}
+
+	if len(ranks) != 3 {
+		t.Fatalf("expected 3 nodes after warm-start Link, got %d", len(ranks))
+	}
+
+	if sum := sumRanks(ranks); math.Abs(sum-1) > 1e-6 {
+		t.Fatalf("ranks summed to %v after warm start, want ~1", sum)
+	}
+
+	for key, rank := range ranks {
+		if rank <= 0 {
+			t.Fatalf("rank[%s] = %v, want > 0", key, rank)
+		}
+	}
+}
+
+// TestRankNWarmStartsAfterLink mirrors RankN's doc comment, which
+// describes warm-starting a streaming graph by calling Link for new edges
+// and then RankN for a bounded number of additional steps, rather than
+// recomputing from scratch with Rank.
+func TestRankNWarmStartsAfterLink(t *testing.T) {
+	graph := NewGraph[string]()
+	graph.Link("a", "b", 1)
+	graph.Link("b", "a", 1)
+
+	graph.RankN(0.85, 20, func(string, float64) {})
+
+	graph.Link("c", "a", 1)
+
+	ranks := map[string]float64{}
+	graph.RankN(0.85, 200, func(id string, rank float64) {
+		ranks[id] = rank
+	})
+
+	if len(ranks) != 3 {
+		t.Fatalf("expected 3 nodes after warm-start Link, got %d", len(ranks))
+	}
+
+	if sum := sumRanks(ranks); math.Abs(sum-1) > 1e-6 {
+		t.Fatalf("ranks summed to %v after warm start, want ~1", sum)
+	}
+}
+
+func TestCompactGraphRankMatchesDenseRank(t *testing.T) {
+	build := func() *Graph[string] {
+		graph := NewGraph[string]()
+		graph.Link("a", "b", 1)
+		graph.Link("b", "c", 1)
+		graph.Link("c", "a", 1)
+		graph.Link("c", "b", 1)
+		graph.Link("b", "a", 2)
+
+		return graph
+	}
+
+	want := map[string]float64{}
+	build().Rank(0.85, 1e-8, func(id string, rank float64) {
+		want[id] = rank
+	})
+
+	got := map[string]float64{}
+	build().Compile().Rank(0.85, 1e-8, func(id string, rank float64) {
+		got[id] = rank
+	})
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d ranks, want %d", len(got), len(want))
+	}
+
+	for id, rank := range want {
+		if diff := math.Abs(got[id] - rank); diff > 1e-6 {
+			t.Fatalf("rank[%s] = %v, want %v (diff %v)", id, got[id], rank, diff)
+		}
+	}
+}
+
+func TestCompactGraphRankMatchesDenseRankAfterPriorRank(t *testing.T) {
+	build := func() *Graph[string] {
+		graph := NewGraph[string]()
+		graph.Link("a", "b", 1)
+		graph.Link("b", "c", 1)
+		graph.Link("c", "a", 1)
+		graph.Link("c", "b", 1)
+		graph.Link("b", "a", 2)
+
+		return graph
+	}
+
+	fresh := build()
+
+	want := map[string]float64{}
+	fresh.Compile().Rank(0.85, 1e-8, func(id string, rank float64) {
+		want[id] = rank
+	})
+
+	warmed := build()
+	warmed.Rank(0.85, 1e-8, func(string, float64) {})
+
+	got := map[string]float64{}
+	warmed.Compile().Rank(0.85, 1e-8, func(id string, rank float64) {
+		got[id] = rank
+	})
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d ranks, want %d", len(got), len(want))
+	}
+
+	for id, rank := range want {
+		if diff := math.Abs(got[id] - rank); diff > 1e-6 {
+			t.Fatalf("rank[%s] = %v, want %v (diff %v) after a prior Rank call on the same graph", id, got[id], rank, diff)
+		}
+	}
+}
+
+// TestCompactGraphRankParallelShardsAgree drives CompactGraph.Rank under
+// several different GOMAXPROCS settings (and therefore different
+// source-row shard counts and boundaries) and checks the result is
+// unaffected, guarding against the partial-sum merge or shard boundaries
+// being wrong. Run with `go test -race` to additionally catch a shard
+// writing another shard's partial buffer.
+func TestCompactGraphRankParallelShardsAgree(t *testing.T) {
+	prev := runtime.GOMAXPROCS(0)
+	defer runtime.GOMAXPROCS(prev)
+
+	graph := NewGraph[int64]()
+	for i := int64(0); i < 50; i++ {
+		graph.Link(i, (i+1)%50, 1)
+		graph.Link(i, (i+7)%50, 1)
+	}
+
+	compact := graph.Compile()
+
+	var want map[int64]float64
+
+	for _, workers := range []int{1, 2, 8} {
+		runtime.GOMAXPROCS(workers)
+
+		got := map[int64]float64{}
+		compact.Rank(0.85, 1e-10, func(id int64, rank float64) {
+			got[id] = rank
+		})
+
+		if want == nil {
+			want = got
+
+			continue
+		}
+
+		for id, rank := range want {
+			if diff := math.Abs(got[id] - rank); diff > 1e-9 {
+				t.Fatalf("workers=%d: rank[%d] = %v, want %v (diff %v)", workers, id, got[id], rank, diff)
+			}
+		}
+	}
+}